@@ -0,0 +1,362 @@
+package optidash
+
+import (
+    "fmt"
+    "io"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "github.com/valyala/fastjson"
+)
+
+// BatchResult holds the outcome of a single job processed as part of a batch.
+// Err is set when the job failed, and Value is then nil. For a successful
+// job Value holds the result; a ToDirectory job whose response carried no
+// X-Optidash-Meta header succeeds with both Value and Err nil.
+type BatchResult struct {
+    Name  string
+    Value *fastjson.Value
+    Err   error
+}
+
+// batchJob describes a single item queued on a BatchRequest, before it is
+// turned into a *Request at execution time. If err is set, the job is
+// invalid and is reported as a BatchResult without ever being executed.
+type batchJob struct {
+    source   source
+    reader   interface{}
+    location string
+    name     string
+    err      error
+}
+
+// BatchRequest allows many Upload/Fetch jobs to be run through the same
+// transformation chain in one call, fanning out concurrently instead of
+// issuing requests one at a time.
+type BatchRequest struct {
+    client  *Client
+    jobs    []batchJob
+    workers int
+    names   map[string]int
+
+    optimize  P
+    flip      P
+    resize    P
+    scale     P
+    crop      P
+    watermark P
+    mask      P
+    stylize   P
+    adjust    P
+    auto      P
+    border    P
+    padding   P
+    store     P
+    output    P
+    webhook   P
+    response  P
+    cdn       P
+}
+
+// defaultBatchWorkers is used when Concurrency() has not been called.
+const defaultBatchWorkers = 4
+
+// Batch returns a new BatchRequest builder, sharing the Client's
+// authentication and HTTP client across all of its jobs.
+func (c *Client) Batch() *BatchRequest {
+    return &BatchRequest{
+        client:  c,
+        workers: defaultBatchWorkers,
+    }
+}
+
+// Concurrency sets the number of jobs that are allowed to run at the same
+// time. It defaults to 4. Values below 1 are treated as 1.
+func (b *BatchRequest) Concurrency(n int) *BatchRequest {
+    if n < 1 {
+        n = 1
+    }
+    b.workers = n
+    return b
+}
+
+// Upload queues an Upload() job, accepting either an io.Reader or a string
+// path, exactly like Client.Upload. An optional name can be passed to
+// control the filename used by ToDirectory; when omitted it is derived from
+// the path, or from the job's position in the batch for readers. Any other
+// input type is queued as a failed job, reported via BatchResult.Err
+// instead of panicking when the batch runs.
+func (b *BatchRequest) Upload(input interface{}, name ...string) *BatchRequest {
+    job := batchJob{source: readerSource, reader: input}
+
+    switch v := input.(type) {
+    case io.Reader:
+        job.name = fmt.Sprintf("image-%d", len(b.jobs))
+    case string:
+        job.source = pathSource
+        job.location = v
+        job.name = filepath.Base(v)
+    default:
+        job.err = fmt.Errorf("optidash: Upload only accepts an io.Reader or a string path, got %T", input)
+        job.name = fmt.Sprintf("image-%d", len(b.jobs))
+    }
+
+    if len(name) > 0 {
+        job.name = name[0]
+    }
+    job.name = b.uniqueName(job.name)
+
+    b.jobs = append(b.jobs, job)
+    return b
+}
+
+// Fetch queues a Fetch() job for the given URL. An optional name can be
+// passed to control the filename used by ToDirectory; when omitted it is
+// derived from the last path segment of the URL.
+func (b *BatchRequest) Fetch(location string, name ...string) *BatchRequest {
+    job := batchJob{source: fetchSource, location: location}
+
+    if len(name) > 0 {
+        job.name = name[0]
+    } else if u, err := url.Parse(location); err == nil && filepath.Base(u.Path) != "." {
+        job.name = filepath.Base(u.Path)
+    } else {
+        job.name = fmt.Sprintf("image-%d", len(b.jobs))
+    }
+    job.name = b.uniqueName(job.name)
+
+    b.jobs = append(b.jobs, job)
+    return b
+}
+
+// uniqueName returns name, or a disambiguated variant with a numeric suffix
+// if it collides with a name already queued on this batch. Without this,
+// two jobs deriving the same filename (e.g. from same-named files in
+// different source directories) would race to write the same destination
+// path in ToDirectory. The disambiguated candidate itself is checked (and
+// registered) against b.names too, so it can't collide with a name an
+// earlier job was given explicitly.
+func (b *BatchRequest) uniqueName(name string) string {
+    if b.names == nil {
+        b.names = map[string]int{}
+    }
+
+    if _, taken := b.names[name]; !taken {
+        b.names[name] = 0
+        return name
+    }
+
+    ext := filepath.Ext(name)
+    base := strings.TrimSuffix(name, ext)
+
+    for n := b.names[name] + 1; ; n++ {
+        candidate := fmt.Sprintf("%s-%d%s", base, n, ext)
+        if _, taken := b.names[candidate]; !taken {
+            b.names[name] = n
+            b.names[candidate] = 0
+            return candidate
+        }
+    }
+}
+
+// Optimize adds an image optimization step to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Optimize(data P) *BatchRequest {
+    b.optimize = data
+    return b
+}
+
+// Flip adds an image flipping step to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Flip(data P) *BatchRequest {
+    b.flip = data
+    return b
+}
+
+// Resize adds an image resizing step to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Resize(data P) *BatchRequest {
+    b.resize = data
+    return b
+}
+
+// Scale adds an image scaling step to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Scale(data P) *BatchRequest {
+    b.scale = data
+    return b
+}
+
+// Crop adds an image cropping step to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Crop(data P) *BatchRequest {
+    b.crop = data
+    return b
+}
+
+// Watermark adds a watermark application to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Watermark(data P) *BatchRequest {
+    b.watermark = data
+    return b
+}
+
+// Mask adds application of an elliptical mask to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Mask(data P) *BatchRequest {
+    b.mask = data
+    return b
+}
+
+// Stylize adds filter application to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Stylize(data P) *BatchRequest {
+    b.stylize = data
+    return b
+}
+
+// Adjust adds an visual parameters adjustment to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Adjust(data P) *BatchRequest {
+    b.adjust = data
+    return b
+}
+
+// Auto adds an automatic image enhancement step to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Auto(data P) *BatchRequest {
+    b.auto = data
+    return b
+}
+
+// Border adds adding a border to the image to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Border(data P) *BatchRequest {
+    b.border = data
+    return b
+}
+
+// Padding adds an image padding step to the shared transformation flow.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Padding(data P) *BatchRequest {
+    b.padding = data
+    return b
+}
+
+// Store specifies where the image should be stored after transformations.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Store(data P) *BatchRequest {
+    b.store = data
+    return b
+}
+
+// Output sets the output format and encoding.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) Output(data P) *BatchRequest {
+    b.output = data
+    return b
+}
+
+// CDN configures CDN settings of the platform.
+// Check out Optidash docs for more details.
+func (b *BatchRequest) CDN(data P) *BatchRequest {
+    b.cdn = data
+    return b
+}
+
+// request builds the *Request for a single job, applying the transformation
+// chain shared across the whole batch.
+func (b *BatchRequest) request(job batchJob) *Request {
+    var r *Request
+    if job.source == fetchSource {
+        r = b.client.Fetch(job.location)
+    } else {
+        r = b.client.Upload(job.reader)
+    }
+
+    r.optimize = b.optimize
+    r.flip = b.flip
+    r.resize = b.resize
+    r.scale = b.scale
+    r.crop = b.crop
+    r.watermark = b.watermark
+    r.mask = b.mask
+    r.stylize = b.stylize
+    r.adjust = b.adjust
+    r.auto = b.auto
+    r.border = b.border
+    r.padding = b.padding
+    r.store = b.store
+    r.output = b.output
+    r.webhook = b.webhook
+    r.response = b.response
+    r.cdn = b.cdn
+
+    return r
+}
+
+// Do runs every queued job concurrently, using the configured worker pool,
+// and returns one BatchResult per job in the same order the jobs were
+// queued in.
+func (b *BatchRequest) Do() []*BatchResult {
+    results := make([]*BatchResult, len(b.jobs))
+
+    sem := make(chan struct{}, b.workers)
+    var wg sync.WaitGroup
+
+    for i, job := range b.jobs {
+        wg.Add(1)
+        sem <- struct{}{}
+
+        go func(i int, job batchJob) {
+            defer wg.Done()
+            defer func() { <-sem }()
+
+            if job.err != nil {
+                results[i] = &BatchResult{Name: job.name, Err: job.err}
+                return
+            }
+
+            value, err := b.request(job).ToJSON()
+            results[i] = &BatchResult{Name: job.name, Value: value, Err: err}
+        }(i, job)
+    }
+
+    wg.Wait()
+    return results
+}
+
+// ToDirectory runs every queued job concurrently as a binary request and
+// writes each result into path, using the name derived (or passed) when the
+// job was queued. Files are created with the given perm, truncating any
+// existing file at the destination.
+func (b *BatchRequest) ToDirectory(path string, perm os.FileMode) []*BatchResult {
+    results := make([]*BatchResult, len(b.jobs))
+
+    sem := make(chan struct{}, b.workers)
+    var wg sync.WaitGroup
+
+    for i, job := range b.jobs {
+        wg.Add(1)
+        sem <- struct{}{}
+
+        go func(i int, job batchJob) {
+            defer wg.Done()
+            defer func() { <-sem }()
+
+            if job.err != nil {
+                results[i] = &BatchResult{Name: job.name, Err: job.err}
+                return
+            }
+
+            dest := filepath.Join(path, job.name)
+            value, err := b.request(job).ToFile(dest, perm)
+            results[i] = &BatchResult{Name: job.name, Value: value, Err: err}
+        }(i, job)
+    }
+
+    wg.Wait()
+    return results
+}
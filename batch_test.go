@@ -0,0 +1,117 @@
+package optidash
+
+import (
+    "bytes"
+    "io/ioutil"
+    "net/http"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+// fakeTransport returns a canned binary response for every request, so
+// tests can exercise the batch pipeline without real network access.
+type fakeTransport struct{}
+
+func (fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    header := http.Header{}
+    header.Set("X-Optidash-Meta", `{"success":true}`)
+    return &http.Response{
+        StatusCode: 200,
+        Header:     header,
+        Body:       ioutil.NopCloser(bytes.NewReader([]byte("fake-image-bytes"))),
+    }, nil
+}
+
+func newTestClient() *Client {
+    c, _ := NewClient("test-key")
+    c.Client = &http.Client{Transport: fakeTransport{}}
+    return c
+}
+
+func TestBatchConcurrencyZeroDoesNotDeadlock(t *testing.T) {
+    b := newTestClient().Batch().Concurrency(0)
+    for i := 0; i < 3; i++ {
+        b.Upload(bytes.NewReader([]byte("data")))
+    }
+
+    done := make(chan []*BatchResult, 1)
+    go func() { done <- b.Do() }()
+
+    select {
+    case results := <-done:
+        if len(results) != 3 {
+            t.Fatalf("expected 3 results, got %d", len(results))
+        }
+    case <-time.After(2 * time.Second):
+        t.Fatal("Do() did not return; Concurrency(0) deadlocked")
+    }
+}
+
+func TestBatchUploadInvalidInputDoesNotPanic(t *testing.T) {
+    b := newTestClient().Batch()
+    b.Upload(42)
+
+    results := b.Do()
+    if len(results) != 1 {
+        t.Fatalf("expected 1 result, got %d", len(results))
+    }
+    if results[0].Err == nil {
+        t.Fatal("expected an error for a non-Reader/string input")
+    }
+}
+
+func TestBatchJobNamesDoNotCollide(t *testing.T) {
+    b := newTestClient().Batch()
+    b.Upload("/dirA/photo.jpg")
+    b.Upload("/dirB/photo.jpg")
+
+    if b.jobs[0].name == b.jobs[1].name {
+        t.Fatalf("expected disambiguated names, got %q twice", b.jobs[0].name)
+    }
+}
+
+func TestBatchJobNamesDoNotCollideWithDisambiguatedNames(t *testing.T) {
+    b := newTestClient().Batch()
+    b.Upload(bytes.NewReader([]byte("x")), "photo-1.jpg")
+    b.Upload(bytes.NewReader([]byte("y")), "photo.jpg")
+    b.Upload(bytes.NewReader([]byte("z")), "photo.jpg")
+
+    seen := map[string]bool{}
+    for _, job := range b.jobs {
+        if seen[job.name] {
+            t.Fatalf("job name %q was assigned to more than one job", job.name)
+        }
+        seen[job.name] = true
+    }
+}
+
+func TestBatchToDirectoryWritesDistinctFiles(t *testing.T) {
+    dir := t.TempDir()
+
+    b := newTestClient().Batch()
+    b.Upload(bytes.NewReader([]byte("a")), "photo.jpg")
+    b.Upload(bytes.NewReader([]byte("b")), "photo.jpg")
+
+    results := b.ToDirectory(dir, 0644)
+    for _, result := range results {
+        if result.Err != nil {
+            t.Fatalf("unexpected error: %v", result.Err)
+        }
+    }
+
+    if results[0].Name == results[1].Name {
+        t.Fatalf("expected disambiguated names, got %q twice", results[0].Name)
+    }
+
+    for _, result := range results {
+        data, err := os.ReadFile(filepath.Join(dir, result.Name))
+        if err != nil {
+            t.Fatalf("reading %s: %v", result.Name, err)
+        }
+        if string(data) != "fake-image-bytes" {
+            t.Fatalf("unexpected contents for %s: %q", result.Name, data)
+        }
+    }
+}
@@ -1,9 +1,11 @@
 package optidash
 
 import (
+    "context"
     "errors"
     "io"
     "net/http"
+    "time"
 )
 
 const apiURL = "https://api.optidash.ai/1.0"
@@ -12,6 +14,58 @@ const apiURL = "https://api.optidash.ai/1.0"
 type Client struct {
     Key    string
     Client *http.Client
+
+    retry *RetryConfig
+
+    // OnRetry, when set, is called before every retried attempt with the
+    // 1-based number of the attempt that just failed and the error (or
+    // synthetic error describing the response status) that triggered it.
+    OnRetry func(attempt int, err error)
+
+    // Observer, when set, is notified of every request made through the
+    // client. See ObserverConfig.
+    Observer *ObserverConfig
+}
+
+// RetryConfig configures the retry policy applied to Request.execute() by
+// Client.WithRetry. Requests are retried on network errors, 5xx responses
+// and 429 responses, honoring the Retry-After header when present and
+// falling back to exponential backoff with jitter otherwise.
+type RetryConfig struct {
+    MaxAttempts    int
+    InitialBackoff time.Duration
+    MaxBackoff     time.Duration
+    Jitter         time.Duration
+}
+
+// RequestInfo carries the contextual data passed to ObserverConfig's hooks.
+type RequestInfo struct {
+    // Source is either "upload" or "fetch".
+    Source string
+
+    // Stages lists the transformation steps configured on the request
+    // (e.g. "resize", "crop"), in application order.
+    Stages []string
+
+    // Context is the request's context, or context.Background() if none
+    // was set via Request.Context.
+    Context context.Context
+}
+
+// ObserverConfig wires instrumentation hooks into every request made
+// through a Client. All hooks are optional. OnRequestStart may return an
+// arbitrary value (for example a context carrying a span); that value is
+// passed back, unchanged, as the first argument to the matching
+// OnRequestEnd/OnError call for the same request attempt, so adapters don't
+// need to correlate calls through a shared map. For a failed attempt,
+// OnError runs before OnRequestEnd, so an adapter can record the error on
+// whatever OnRequestStart returned before that value is finalized (e.g. a
+// span is ended) in OnRequestEnd.
+type ObserverConfig struct {
+    OnRequestStart func(info RequestInfo) interface{}
+    OnRequestEnd   func(state interface{}, duration time.Duration, statusCode int, bytesSent, bytesReceived int64)
+    OnRetry        func(attempt int, err error)
+    OnError        func(state interface{}, err error)
 }
 
 // NewClient returns a new client using the given config.
@@ -61,3 +115,20 @@ func (c *Client) Fetch(url string) *Request {
         location: url,
     }
 }
+
+// WithRetry enables automatic retries, with exponential backoff, for every
+// request made through the client. Uploads sourced from a plain io.Reader
+// can only be retried when that reader also implements io.Seeker, since the
+// bytes already sent need to be replayed; streaming readers without Seek
+// are always sent in a single attempt regardless of this configuration.
+func (c *Client) WithRetry(cfg RetryConfig) *Client {
+    c.retry = &cfg
+    return c
+}
+
+// WithObserver enables instrumentation hooks for every request made
+// through the client. See ObserverConfig.
+func (c *Client) WithObserver(cfg ObserverConfig) *Client {
+    c.Observer = &cfg
+    return c
+}
@@ -0,0 +1,82 @@
+// Package otelopt adapts optidash.ObserverConfig to OpenTelemetry, emitting
+// one span per request plus a request duration histogram and error counter.
+package otelopt
+
+import (
+    "context"
+    "time"
+
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/metric"
+    "go.opentelemetry.io/otel/trace"
+
+    optidash "github.com/optidash-ai/optidash-go"
+)
+
+// state is the per-request value threaded from OnRequestStart through to
+// OnRequestEnd/OnError.
+type state struct {
+    ctx  context.Context
+    span trace.Span
+}
+
+// New returns an optidash.ObserverConfig that records a span per request on
+// tracer, with attributes for the request's source (upload/fetch) and the
+// transformation stages configured on it, and records request duration and
+// error counts on meter.
+func New(tracer trace.Tracer, meter metric.Meter) (optidash.ObserverConfig, error) {
+    duration, err := meter.Float64Histogram(
+        "optidash.request.duration",
+        metric.WithDescription("Duration of Optidash API requests"),
+        metric.WithUnit("ms"),
+    )
+    if err != nil {
+        return optidash.ObserverConfig{}, err
+    }
+
+    errorCount, err := meter.Int64Counter(
+        "optidash.request.errors",
+        metric.WithDescription("Number of failed Optidash API requests"),
+    )
+    if err != nil {
+        return optidash.ObserverConfig{}, err
+    }
+
+    return optidash.ObserverConfig{
+        OnRequestStart: func(info optidash.RequestInfo) interface{} {
+            ctx, span := tracer.Start(info.Context, "optidash."+info.Source,
+                trace.WithAttributes(
+                    attribute.String("optidash.source", info.Source),
+                    attribute.StringSlice("optidash.stages", info.Stages),
+                ),
+            )
+            return &state{ctx: ctx, span: span}
+        },
+        OnRequestEnd: func(s interface{}, d time.Duration, statusCode int, bytesSent, bytesReceived int64) {
+            st, ok := s.(*state)
+            if !ok || st == nil {
+                return
+            }
+
+            st.span.SetAttributes(
+                attribute.Int("optidash.status_code", statusCode),
+                attribute.Int64("optidash.bytes_sent", bytesSent),
+                attribute.Int64("optidash.bytes_received", bytesReceived),
+            )
+            st.span.End()
+
+            duration.Record(st.ctx, float64(d.Milliseconds()))
+        },
+        OnError: func(s interface{}, err error) {
+            st, ok := s.(*state)
+            if !ok || st == nil {
+                errorCount.Add(context.Background(), 1)
+                return
+            }
+
+            st.span.SetStatus(codes.Error, err.Error())
+            errorCount.Add(st.ctx, 1)
+        },
+    }, nil
+}
@@ -0,0 +1,54 @@
+// Package promopt adapts optidash.ObserverConfig to Prometheus, registering
+// a request duration histogram and error counter against a
+// prometheus.Registerer.
+package promopt
+
+import (
+    "strconv"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+
+    optidash "github.com/optidash-ai/optidash-go"
+)
+
+// New registers the request duration histogram and error counter on reg and
+// returns an optidash.ObserverConfig that feeds them from every request
+// made through a Client.
+func New(reg prometheus.Registerer) (optidash.ObserverConfig, error) {
+    duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+        Name: "optidash_request_duration_seconds",
+        Help: "Duration of Optidash API requests, in seconds.",
+    }, []string{"source", "status_code"})
+
+    errorCount := prometheus.NewCounterVec(prometheus.CounterOpts{
+        Name: "optidash_request_errors_total",
+        Help: "Number of failed Optidash API requests.",
+    }, []string{"source"})
+
+    for _, collector := range []prometheus.Collector{duration, errorCount} {
+        if err := reg.Register(collector); err != nil {
+            return optidash.ObserverConfig{}, err
+        }
+    }
+
+    return optidash.ObserverConfig{
+        OnRequestStart: func(info optidash.RequestInfo) interface{} {
+            return info.Source
+        },
+        OnRequestEnd: func(s interface{}, d time.Duration, statusCode int, bytesSent, bytesReceived int64) {
+            source, _ := s.(string)
+            duration.WithLabelValues(source, statusCodeLabel(statusCode)).Observe(d.Seconds())
+        },
+        OnError: func(s interface{}, err error) {
+            source, _ := s.(string)
+            errorCount.WithLabelValues(source).Inc()
+        },
+    }, nil
+}
+
+// statusCodeLabel turns an HTTP status code into a label value, using "0"
+// for requests that never received a response (network errors).
+func statusCodeLabel(statusCode int) string {
+    return strconv.Itoa(statusCode)
+}
@@ -4,11 +4,15 @@ import (
     "bytes"
     "context"
     "encoding/json"
+    "fmt"
     "io"
     "io/ioutil"
+    "math/rand"
     "mime/multipart"
     "net/http"
     "os"
+    "strconv"
+    "time"
     "github.com/valyala/fastjson"
 )
 
@@ -17,10 +21,11 @@ type Request struct {
     client *Client
     http   *http.Client
 
-    source   source
-    reader   io.Reader
-    location string
-    context  context.Context
+    source        source
+    reader        io.Reader
+    location      string
+    context       context.Context
+    contentLength int64
 
     optimize  P
     flip      P
@@ -167,6 +172,18 @@ func (r *Request) Webhook(data P) *Request {
     return r
 }
 
+// WebhookCorrelate sets a caller-supplied correlation ID on the webhook
+// configuration. Optidash echoes it back in the delivered payload's
+// metadata as Result.CorrelationID, so the optidash/webhook package can
+// match a delivery against the request that triggered it.
+func (r *Request) WebhookCorrelate(id string) *Request {
+    if r.webhook == nil {
+        r.webhook = P{}
+    }
+    r.webhook["correlationId"] = id
+    return r
+}
+
 // CDN configures CDN settings of the platform.
 // Check out Optidash docs for more details.
 func (r *Request) CDN(data P) *Request {
@@ -180,6 +197,18 @@ func (r *Request) Context(ctx context.Context) *Request {
     return r
 }
 
+// ContentLength sets the Content-Length header of the request explicitly.
+// Uploads are streamed directly into the HTTP request body and sent using
+// chunked transfer encoding by default, since the size of an io.Reader
+// source is generally unknown ahead of time. If the caller already knows
+// the size of the multipart body (for example by combining os.Stat on a
+// path source with the overhead of the surrounding multipart envelope),
+// passing it here avoids chunked encoding.
+func (r *Request) ContentLength(n int64) *Request {
+    r.contentLength = n
+    return r
+}
+
 // Internal execution function of HTTP requests.
 func (r *Request) execute() (*http.Response, error) {
     // First use this hack to create a map with params
@@ -245,12 +274,15 @@ func (r *Request) execute() (*http.Response, error) {
         url         string
         contentType string
         body        io.Reader
+        pipeReader  *io.PipeReader
+        knownLength int64 = -1
     )
     if r.source == fetchSource {
         // .Fetch(url) is straightforward
         url = apiURL + "/fetch"
         body = bytes.NewReader(pb)
         contentType = "application/json"
+        knownLength = int64(len(pb))
     } else if r.source == readerSource || r.source == pathSource {
         // .Upload(<any>) has two cases
         url = apiURL + "/upload"
@@ -269,29 +301,38 @@ func (r *Request) execute() (*http.Response, error) {
             file = fs
         }
 
-        // Prepare a buffer for the multipart body writer
-        buf := &bytes.Buffer{}
-        body = buf
-        writer := multipart.NewWriter(buf)
-
-        // Create the file upload part
-        part, err := writer.CreateFormFile("file", "")
-        if err != nil {
-            return nil, err
-        }
-        if _, err := io.Copy(part, file); err != nil {
-            return nil, err
-        }
+        // Stream the multipart body through a pipe instead of buffering the
+        // whole upload in memory, so http.NewRequest gets a plain io.Reader
+        // and the request is sent using chunked transfer encoding.
+        pr, pw := io.Pipe()
+        body = pr
+        pipeReader = pr
+        writer := multipart.NewWriter(pw)
+
+        go func() {
+            // Write the "data" field first, then the file part.
+            if err := writer.WriteField("data", string(pb)); err != nil {
+                pw.CloseWithError(err)
+                return
+            }
 
-        // Insert the JSON data into a "data" field
-        if err := writer.WriteField("data", string(pb)); err != nil {
-            return nil, err
-        }
+            part, err := writer.CreateFormFile("file", "")
+            if err != nil {
+                pw.CloseWithError(err)
+                return
+            }
+            if _, err := io.Copy(part, file); err != nil {
+                pw.CloseWithError(err)
+                return
+            }
 
-        // End the writing
-        if err := writer.Close(); err != nil {
-            return nil, err
-        }
+            // End the writing, then close the pipe so the reader sees EOF.
+            if err := writer.Close(); err != nil {
+                pw.CloseWithError(err)
+                return
+            }
+            pw.Close()
+        }()
 
         // Set the content type accordingly
         contentType = writer.FormDataContentType()
@@ -300,8 +341,12 @@ func (r *Request) execute() (*http.Response, error) {
         return nil, ErrInvalidSourceType
     }
 
+    // Track bytes actually read off the body by the HTTP transport, for
+    // observability hooks.
+    sent := &countingReader{r: body}
+
     // Create a new HTTP request using previously computed data.
-    request, err := http.NewRequest("POST", url, body)
+    request, err := http.NewRequest("POST", url, sent)
     if err != nil {
         return nil, err
     }
@@ -309,6 +354,22 @@ func (r *Request) execute() (*http.Response, error) {
     // Apply headers - Content-Type, Binary and Authorization
     request.Header.Set("Content-Type", contentType)
 
+    // sent wraps body in a type http.NewRequest's internal type-switch
+    // doesn't recognize, so it can no longer infer Content-Length on its
+    // own (e.g. for the /fetch JSON body, whose length is trivially known
+    // from pb). Set it explicitly wherever the length is known upfront, so
+    // those requests still go out with a proper Content-Length instead of
+    // falling back to chunked encoding.
+    if knownLength >= 0 {
+        request.ContentLength = knownLength
+    }
+
+    // If the caller told us the size of the body upfront, pass it along so
+    // the server gets a Content-Length header instead of chunked encoding.
+    if r.contentLength > 0 {
+        request.ContentLength = r.contentLength
+    }
+
     // Set the context of the request
     if r.context != nil {
         request = request.WithContext(r.context)
@@ -322,14 +383,246 @@ func (r *Request) execute() (*http.Response, error) {
 
     request.SetBasicAuth(r.client.Key, "")
 
+    // Notify observability hooks, if any are configured, and thread
+    // whatever state OnRequestStart returns through to OnRequestEnd/OnError.
+    observer := r.client.Observer
+    var state interface{}
+    if observer != nil && observer.OnRequestStart != nil {
+        state = observer.OnRequestStart(RequestInfo{
+            Source:  r.sourceName(),
+            Stages:  r.stages(),
+            Context: request.Context(),
+        })
+    }
+
     // Run the request using the passed Client
-    return r.http.Do(request)
+    start := time.Now()
+    resp, doErr := r.http.Do(request)
+    duration := time.Since(start)
+
+    // http.Do may return before the transport has fully drained the request
+    // body (e.g. the server replies 429/5xx without reading it). Close the
+    // pipe's read side so the writer goroutine's blocked Write/WriteField
+    // unblocks with io.ErrClosedPipe instead of leaking forever; this is a
+    // no-op if the writer already finished and closed the pipe itself.
+    if pipeReader != nil {
+        pipeReader.Close()
+    }
+
+    if observer != nil {
+        statusCode := 0
+        bytesReceived := int64(-1)
+        if resp != nil {
+            statusCode = resp.StatusCode
+            bytesReceived = resp.ContentLength
+        }
+        // OnError runs before OnRequestEnd so adapters can record an error
+        // status on a span (or similar) before it gets finalized.
+        if doErr != nil && observer.OnError != nil {
+            observer.OnError(state, doErr)
+        }
+        if observer.OnRequestEnd != nil {
+            observer.OnRequestEnd(state, duration, statusCode, sent.n, bytesReceived)
+        }
+    }
+
+    return resp, doErr
+}
+
+// countingReader wraps an io.Reader, counting the bytes read through it.
+type countingReader struct {
+    r io.Reader
+    n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+    n, err := c.r.Read(p)
+    c.n += int64(n)
+    return n, err
+}
+
+// Close forwards to the wrapped reader's Close, if it implements io.Closer,
+// so wrapping doesn't break cleanup of the underlying io.Pipe.
+func (c *countingReader) Close() error {
+    if closer, ok := c.r.(io.Closer); ok {
+        return closer.Close()
+    }
+    return nil
+}
+
+// sourceName returns a short identifier for the request's source, used by
+// observability hooks.
+func (r *Request) sourceName() string {
+    if r.source == fetchSource {
+        return "fetch"
+    }
+    return "upload"
+}
+
+// stages returns the names of the transformation steps configured on the
+// request, in application order, for use by observability hooks.
+func (r *Request) stages() []string {
+    var stages []string
+    if r.optimize != nil {
+        stages = append(stages, "optimize")
+    }
+    if r.flip != nil {
+        stages = append(stages, "flip")
+    }
+    if r.resize != nil {
+        stages = append(stages, "resize")
+    }
+    if r.scale != nil {
+        stages = append(stages, "scale")
+    }
+    if r.crop != nil {
+        stages = append(stages, "crop")
+    }
+    if r.watermark != nil {
+        stages = append(stages, "watermark")
+    }
+    if r.mask != nil {
+        stages = append(stages, "mask")
+    }
+    if r.stylize != nil {
+        stages = append(stages, "stylize")
+    }
+    if r.adjust != nil {
+        stages = append(stages, "adjust")
+    }
+    if r.auto != nil {
+        stages = append(stages, "auto")
+    }
+    if r.border != nil {
+        stages = append(stages, "border")
+    }
+    if r.padding != nil {
+        stages = append(stages, "padding")
+    }
+    if r.store != nil {
+        stages = append(stages, "store")
+    }
+    if r.output != nil {
+        stages = append(stages, "output")
+    }
+    if r.webhook != nil {
+        stages = append(stages, "webhook")
+    }
+    if r.cdn != nil {
+        stages = append(stages, "cdn")
+    }
+    return stages
+}
+
+// retryableStatus reports whether a response status code should be retried.
+func retryableStatus(code int) bool {
+    return code == http.StatusTooManyRequests || (code >= 500 && code < 600)
+}
+
+// retryAfter parses the Retry-After header, supporting both the
+// delay-in-seconds and HTTP-date forms.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+    value := resp.Header.Get("Retry-After")
+    if value == "" {
+        return 0, false
+    }
+
+    if seconds, err := strconv.Atoi(value); err == nil {
+        return time.Duration(seconds) * time.Second, true
+    }
+
+    if when, err := http.ParseTime(value); err == nil {
+        return time.Until(when), true
+    }
+
+    return 0, false
+}
+
+// executeWithRetry calls execute(), retrying according to the Client's
+// RetryConfig (if any) on network errors, 5xx responses and 429 responses.
+func (r *Request) executeWithRetry() (*http.Response, error) {
+    cfg := r.client.retry
+    if cfg == nil {
+        return r.execute()
+    }
+
+    maxAttempts := cfg.MaxAttempts
+    if maxAttempts < 1 {
+        maxAttempts = 1
+    }
+
+    // Streaming io.Reader uploads can only be replayed if they're seekable;
+    // otherwise the first attempt is the only attempt.
+    seeker, seekable := r.reader.(io.Seeker)
+    if r.source == readerSource && !seekable {
+        maxAttempts = 1
+    }
+
+    backoff := cfg.InitialBackoff
+
+    var resp *http.Response
+    var err error
+    for attempt := 1; attempt <= maxAttempts; attempt++ {
+        if attempt > 1 && seekable {
+            if _, serr := seeker.Seek(0, io.SeekStart); serr != nil {
+                return nil, serr
+            }
+        }
+
+        resp, err = r.execute()
+
+        retry := err != nil || (resp != nil && retryableStatus(resp.StatusCode))
+        if !retry || attempt == maxAttempts {
+            return resp, err
+        }
+
+        retryErr := err
+        if retryErr == nil {
+            retryErr = fmt.Errorf("optidash: retrying after status %d", resp.StatusCode)
+        }
+        if r.client.OnRetry != nil {
+            r.client.OnRetry(attempt, retryErr)
+        }
+        if r.client.Observer != nil && r.client.Observer.OnRetry != nil {
+            r.client.Observer.OnRetry(attempt, retryErr)
+        }
+
+        wait := backoff
+        fromHeader := false
+        if resp != nil {
+            if ra, ok := retryAfter(resp); ok {
+                wait = ra
+                fromHeader = true
+            }
+            resp.Body.Close()
+        }
+        if cfg.Jitter > 0 {
+            wait += time.Duration(rand.Int63n(int64(cfg.Jitter)))
+        }
+        // MaxBackoff caps the computed backoff only, never a Retry-After delay.
+        if !fromHeader && cfg.MaxBackoff > 0 && wait > cfg.MaxBackoff {
+            wait = cfg.MaxBackoff
+        }
+        time.Sleep(wait)
+
+        if backoff > 0 {
+            backoff *= 2
+            if cfg.MaxBackoff > 0 && backoff > cfg.MaxBackoff {
+                backoff = cfg.MaxBackoff
+            }
+        }
+    }
+
+    return resp, err
 }
 
 // ToJSON runs the request and returns a fastjson.Value with a result from the API.
+//
+// Deprecated: fastjson.Value is tied to the lifetime of its parser and can
+// be invalidated if that parser is reused. Use Do instead.
 func (r *Request) ToJSON() (*fastjson.Value, error) {
     // Run the request
-    resp, err := r.execute()
+    resp, err := r.executeWithRetry()
     if err != nil {
         return nil, err
     }
@@ -391,6 +684,9 @@ func (r *Request) ToJSON() (*fastjson.Value, error) {
 //  - error that should be nil if everything succeeded
 // Due to the fact that ToReader performs a binary request, using Webhook
 // and Store is forbidden.
+//
+// Deprecated: fastjson.Value is tied to the lifetime of its parser and can
+// be invalidated if that parser is reused. Use DoBinary instead.
 func (r *Request) ToReader() (*fastjson.Value, io.ReadCloser, error) {
     if r.webhook != nil {
         return nil, nil, ErrBinaryWebhook
@@ -406,7 +702,7 @@ func (r *Request) ToReader() (*fastjson.Value, io.ReadCloser, error) {
     }
 
     // Execute the request
-    resp, err := r.execute()
+    resp, err := r.executeWithRetry()
 
     // Clean up the body if execution fails
     var succeeded bool
@@ -0,0 +1,232 @@
+package optidash
+
+import (
+    "bytes"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "runtime"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// earlyResponseTransport answers every request with a 429 without reading
+// the request body at all, simulating a rate-limiting backend that replies
+// before draining a slow/large upload.
+type earlyResponseTransport struct{}
+
+func (earlyResponseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    return &http.Response{
+        StatusCode: http.StatusTooManyRequests,
+        Header:     http.Header{},
+        Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+    }, nil
+}
+
+func TestExecuteDoesNotLeakUploadGoroutineOnEarlyResponse(t *testing.T) {
+    c := newTestClient()
+    c.Client = &http.Client{Transport: earlyResponseTransport{}}
+
+    before := runtime.NumGoroutine()
+
+    for i := 0; i < 20; i++ {
+        req := c.Upload(bytes.NewReader([]byte("some upload bytes")))
+        resp, err := req.execute()
+        if err != nil {
+            t.Fatalf("execute: %v", err)
+        }
+        resp.Body.Close()
+    }
+
+    deadline := time.Now().Add(2 * time.Second)
+    for {
+        runtime.Gosched()
+        if runtime.NumGoroutine() <= before+2 {
+            return
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("multipart writer goroutines leaked: before=%d after=%d", before, runtime.NumGoroutine())
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+}
+
+// flakyTransport drains the request body like a real server would, then
+// fails with a 503 for the first `failures` attempts before succeeding.
+type flakyTransport struct {
+    attempts *int32
+    failures int
+}
+
+func (f flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    n := atomic.AddInt32(f.attempts, 1)
+    io.Copy(ioutil.Discard, req.Body)
+    req.Body.Close()
+
+    if int(n) <= f.failures {
+        return &http.Response{
+            StatusCode: http.StatusServiceUnavailable,
+            Header:     http.Header{},
+            Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+        }, nil
+    }
+
+    header := http.Header{}
+    header.Set("X-Optidash-Meta", `{"success":true}`)
+    return &http.Response{
+        StatusCode: 200,
+        Header:     header,
+        Body:       ioutil.NopCloser(bytes.NewReader([]byte("fake-image-bytes"))),
+    }, nil
+}
+
+func TestExecuteWithRetrySucceedsAfterServiceUnavailable(t *testing.T) {
+    var attempts int32
+    c := newTestClient()
+    c.Client = &http.Client{Transport: flakyTransport{attempts: &attempts, failures: 2}}
+    c = c.WithRetry(RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+    // bytes.Reader implements io.Seeker, so it can be replayed across attempts.
+    req := c.Upload(bytes.NewReader([]byte("data")))
+    resp, err := req.executeWithRetry()
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != 200 {
+        t.Fatalf("expected 200, got %d", resp.StatusCode)
+    }
+    if got := atomic.LoadInt32(&attempts); got != 3 {
+        t.Fatalf("expected 3 attempts, got %d", got)
+    }
+}
+
+// onceReader wraps an io.Reader without exposing io.Seeker, regardless of
+// what the underlying reader implements.
+type onceReader struct {
+    r io.Reader
+}
+
+func (o *onceReader) Read(p []byte) (int, error) {
+    return o.r.Read(p)
+}
+
+func TestExecuteWithRetryNonSeekableReaderIsSingleAttempt(t *testing.T) {
+    var attempts int32
+    c := newTestClient()
+    c.Client = &http.Client{Transport: flakyTransport{attempts: &attempts, failures: 2}}
+    c = c.WithRetry(RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+    req := c.Upload(&onceReader{r: bytes.NewReader([]byte("data"))})
+    resp, _ := req.executeWithRetry()
+    if resp != nil {
+        resp.Body.Close()
+    }
+
+    if got := atomic.LoadInt32(&attempts); got != 1 {
+        t.Fatalf("expected exactly 1 attempt for a non-seekable reader, got %d", got)
+    }
+}
+
+func TestObserverHooksFireAroundASuccessfulRequest(t *testing.T) {
+    c := newTestClient()
+    c.Client = &http.Client{Transport: cannedTransport{
+        statusCode: 200,
+        body:       `{"success":true}`,
+    }}
+
+    var started, ended bool
+    var gotSource string
+    c.WithObserver(ObserverConfig{
+        OnRequestStart: func(info RequestInfo) interface{} {
+            started = true
+            gotSource = info.Source
+            return "state"
+        },
+        OnRequestEnd: func(state interface{}, duration time.Duration, statusCode int, bytesSent, bytesReceived int64) {
+            ended = true
+            if state != "state" {
+                t.Fatalf("expected state passed through from OnRequestStart, got %v", state)
+            }
+            if statusCode != 200 {
+                t.Fatalf("expected status 200, got %d", statusCode)
+            }
+        },
+        OnError: func(state interface{}, err error) {
+            t.Fatalf("OnError should not fire on success, got %v", err)
+        },
+    })
+
+    resp, err := c.Upload(bytes.NewReader([]byte("data"))).execute()
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    resp.Body.Close()
+
+    if !started || !ended {
+        t.Fatalf("expected both OnRequestStart and OnRequestEnd to fire, got started=%v ended=%v", started, ended)
+    }
+    if gotSource != "upload" {
+        t.Fatalf("expected source %q, got %q", "upload", gotSource)
+    }
+}
+
+func TestObserverOnRetryFiresBeforeEachRetriedAttempt(t *testing.T) {
+    var attempts int32
+    c := newTestClient()
+    c.Client = &http.Client{Transport: flakyTransport{attempts: &attempts, failures: 2}}
+    c.WithRetry(RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+    var retries int32
+    c.WithObserver(ObserverConfig{
+        OnRetry: func(attempt int, err error) {
+            atomic.AddInt32(&retries, 1)
+        },
+    })
+
+    resp, err := c.Upload(bytes.NewReader([]byte("data"))).executeWithRetry()
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    defer resp.Body.Close()
+
+    if got := atomic.LoadInt32(&retries); got != 2 {
+        t.Fatalf("expected OnRetry to fire twice, got %d", got)
+    }
+}
+
+// capturingTransport records the ContentLength of the request it receives
+// and answers with a minimal successful response.
+type capturingTransport struct {
+    gotContentLength *int64
+}
+
+func (c capturingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    *c.gotContentLength = req.ContentLength
+    io.Copy(ioutil.Discard, req.Body)
+    req.Body.Close()
+
+    return &http.Response{
+        StatusCode: 200,
+        Header:     http.Header{},
+        Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"success":true}`))),
+    }, nil
+}
+
+func TestExecuteSetsContentLengthForFetchRequests(t *testing.T) {
+    var got int64
+    c := newTestClient()
+    c.Client = &http.Client{Transport: capturingTransport{gotContentLength: &got}}
+
+    resp, err := c.Fetch("https://example.com/image.jpg").execute()
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    resp.Body.Close()
+
+    if got <= 0 {
+        t.Fatalf("expected a positive Content-Length on the /fetch request, got %d", got)
+    }
+}
@@ -0,0 +1,162 @@
+package optidash
+
+import (
+    "encoding/json"
+    "io"
+    "io/ioutil"
+)
+
+// Result is the decoded response returned by Request.Do(), and the meta
+// object returned alongside binary responses from Request.DoBinary().
+type Result struct {
+    Success bool   `json:"success"`
+    Code    int    `json:"code,omitempty"`
+    Message string `json:"message,omitempty"`
+
+    Input  *OutputInfo `json:"input,omitempty"`
+    Output *OutputInfo `json:"output,omitempty"`
+    CDN    *CDNInfo    `json:"cdn,omitempty"`
+    Store  *StoreInfo  `json:"store,omitempty"`
+
+    // CorrelationID echoes the value set via Request.WebhookCorrelate, so a
+    // webhook delivery can be matched back to the request that triggered it.
+    CorrelationID string `json:"correlationId,omitempty"`
+}
+
+// Meta is kept as an alias of Result for readers of the Optidash docs, which
+// refer to this object as "meta" when it's returned via the
+// X-Optidash-Meta header of a binary response.
+type Meta = Result
+
+// OutputInfo describes a single image, either the original input or the
+// transformed output.
+type OutputInfo struct {
+    Format string `json:"format,omitempty"`
+    Width  int    `json:"width,omitempty"`
+    Height int    `json:"height,omitempty"`
+    Size   int64  `json:"size,omitempty"`
+    URL    string `json:"url,omitempty"`
+}
+
+// CDNInfo describes the CDN location of a processed image, populated when
+// Request.CDN is configured.
+type CDNInfo struct {
+    URL string `json:"url,omitempty"`
+}
+
+// StoreInfo describes where a processed image was stored, populated when
+// Request.Store is configured.
+type StoreInfo struct {
+    Provider string `json:"provider,omitempty"`
+    Path     string `json:"path,omitempty"`
+    URL      string `json:"url,omitempty"`
+}
+
+// errorFromResult turns an unsuccessful Result into an *OptidashError.
+func errorFromResult(result *Result) error {
+    return &OptidashError{
+        Code:    result.Code,
+        Message: result.Message,
+    }
+}
+
+// decodeResult unmarshals body into a *Result, returning ErrNoSuccess if the
+// "success" field is missing from the payload entirely, rather than letting
+// it default to the zero value and be mistaken for an unsuccessful-but-valid
+// response. Mirrors the presence check ToJSON does against fastjson.
+func decodeResult(body []byte) (*Result, error) {
+    var presence map[string]json.RawMessage
+    if err := json.Unmarshal(body, &presence); err != nil {
+        return nil, err
+    }
+    if _, ok := presence["success"]; !ok {
+        return nil, ErrNoSuccess
+    }
+
+    var result Result
+    if err := json.Unmarshal(body, &result); err != nil {
+        return nil, err
+    }
+
+    return &result, nil
+}
+
+// Do runs the request and decodes the JSON response into a *Result, using
+// encoding/json instead of fastjson. Prefer this over ToJSON.
+func (r *Request) Do() (*Result, error) {
+    resp, err := r.executeWithRetry()
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    body, err := ioutil.ReadAll(resp.Body)
+    if err != nil {
+        return nil, err
+    }
+
+    result, err := decodeResult(body)
+    if err != nil {
+        return nil, err
+    }
+
+    if !result.Success {
+        return nil, errorFromResult(result)
+    }
+
+    return result, nil
+}
+
+// DoBinary runs the request as a binary request and decodes the
+// X-Optidash-Meta response header into a *Result, using encoding/json
+// instead of fastjson. Prefer this over ToReader.
+// Due to the fact that DoBinary performs a binary request, using Webhook
+// and Store is forbidden.
+func (r *Request) DoBinary() (*Result, io.ReadCloser, error) {
+    if r.webhook != nil {
+        return nil, nil, ErrBinaryWebhook
+    }
+
+    if r.store != nil {
+        return nil, nil, ErrBinaryStorage
+    }
+
+    // Gets embedded into the request
+    r.response = P{
+        "mode": "binary",
+    }
+
+    // Execute the request
+    resp, err := r.executeWithRetry()
+
+    // Clean up the body if execution fails
+    var succeeded bool
+    defer func() {
+        if !succeeded && resp != nil && resp.Body != nil {
+            resp.Body.Close()
+        }
+    }()
+
+    if err != nil {
+        return nil, nil, err
+    }
+
+    // Decode the meta object, if present.
+    var result *Result
+    if sm := resp.Header.Get("X-Optidash-Meta"); sm != "" {
+        result, err = decodeResult([]byte(sm))
+        if err != nil {
+            return nil, nil, err
+        }
+
+        if !result.Success {
+            return nil, nil, errorFromResult(result)
+        }
+    }
+
+    // Make sure that the defer function won't close the body
+    succeeded = true
+
+    // Everything succeeded.
+    return result, resp.Body, nil
+}
@@ -0,0 +1,117 @@
+package optidash
+
+import (
+    "bytes"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "testing"
+)
+
+type cannedTransport struct {
+    statusCode int
+    header     http.Header
+    body       string
+}
+
+func (c cannedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    io.Copy(ioutil.Discard, req.Body)
+    req.Body.Close()
+
+    header := c.header
+    if header == nil {
+        header = http.Header{}
+    }
+    return &http.Response{
+        StatusCode: c.statusCode,
+        Header:     header,
+        Body:       ioutil.NopCloser(bytes.NewReader([]byte(c.body))),
+    }, nil
+}
+
+func TestDoReturnsResultOnSuccess(t *testing.T) {
+    c := newTestClient()
+    c.Client = &http.Client{Transport: cannedTransport{
+        statusCode: 200,
+        body:       `{"success":true,"output":{"format":"jpg","width":10,"height":20}}`,
+    }}
+
+    result, err := c.Upload(bytes.NewReader([]byte("data"))).Do()
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if result.Output == nil || result.Output.Format != "jpg" {
+        t.Fatalf("unexpected result: %+v", result)
+    }
+}
+
+func TestDoReturnsOptidashErrorOnFailure(t *testing.T) {
+    c := newTestClient()
+    c.Client = &http.Client{Transport: cannedTransport{
+        statusCode: 200,
+        body:       `{"success":false,"code":42,"message":"boom"}`,
+    }}
+
+    _, err := c.Upload(bytes.NewReader([]byte("data"))).Do()
+    oerr, ok := err.(*OptidashError)
+    if !ok {
+        t.Fatalf("expected *OptidashError, got %T (%v)", err, err)
+    }
+    if oerr.Code != 42 || oerr.Message != "boom" {
+        t.Fatalf("unexpected error: %+v", oerr)
+    }
+}
+
+func TestDoReturnsErrNoSuccessWhenFieldIsMissing(t *testing.T) {
+    c := newTestClient()
+    c.Client = &http.Client{Transport: cannedTransport{
+        statusCode: 200,
+        body:       `{"weird":"payload"}`,
+    }}
+
+    _, err := c.Upload(bytes.NewReader([]byte("data"))).Do()
+    if err != ErrNoSuccess {
+        t.Fatalf("expected ErrNoSuccess, got %v", err)
+    }
+}
+
+func TestDoBinaryReturnsMetaAndBody(t *testing.T) {
+    c := newTestClient()
+    header := http.Header{}
+    header.Set("X-Optidash-Meta", `{"success":true,"output":{"format":"png"}}`)
+    c.Client = &http.Client{Transport: cannedTransport{
+        statusCode: 200,
+        header:     header,
+        body:       "binary-image-bytes",
+    }}
+
+    result, body, err := c.Upload(bytes.NewReader([]byte("data"))).DoBinary()
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    defer body.Close()
+
+    if result.Output == nil || result.Output.Format != "png" {
+        t.Fatalf("unexpected result: %+v", result)
+    }
+    data, _ := ioutil.ReadAll(body)
+    if string(data) != "binary-image-bytes" {
+        t.Fatalf("unexpected body: %q", data)
+    }
+}
+
+func TestDoBinaryReturnsErrNoSuccessWhenMetaFieldIsMissing(t *testing.T) {
+    c := newTestClient()
+    header := http.Header{}
+    header.Set("X-Optidash-Meta", `{"weird":"payload"}`)
+    c.Client = &http.Client{Transport: cannedTransport{
+        statusCode: 200,
+        header:     header,
+        body:       "binary-image-bytes",
+    }}
+
+    _, _, err := c.Upload(bytes.NewReader([]byte("data"))).DoBinary()
+    if err != ErrNoSuccess {
+        t.Fatalf("expected ErrNoSuccess, got %v", err)
+    }
+}
@@ -0,0 +1,123 @@
+// Package webhook provides an http.Handler that receives asynchronous
+// deliveries configured via Request.Webhook, closing the loop that the
+// optidash package otherwise leaves entirely to the user.
+package webhook
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "io"
+    "io/ioutil"
+    "mime"
+    "mime/multipart"
+    "net/http"
+    "strings"
+
+    optidash "github.com/optidash-ai/optidash-go"
+)
+
+// SignatureHeader is the HTTP header Optidash sets with the HMAC-SHA256
+// signature of the raw webhook body, keyed with the Handler's Secret.
+const SignatureHeader = "X-Optidash-Signature"
+
+// Handler receives Optidash webhook deliveries, verifies their signature and
+// dispatches the decoded payload to Callback. It implements http.Handler and
+// can be mounted directly on any ServeMux.
+type Handler struct {
+    // Secret is the webhook signing secret configured on the Optidash
+    // dashboard, used to verify SignatureHeader.
+    Secret string
+
+    // Callback is invoked for every verified delivery. binary streams any
+    // attached output file and is nil for deliveries carrying only JSON.
+    Callback func(ctx context.Context, result *optidash.Result, binary io.Reader)
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+    body, err := ioutil.ReadAll(req.Body)
+    if err != nil {
+        http.Error(w, "optidash: failed to read webhook body", http.StatusBadRequest)
+        return
+    }
+    defer req.Body.Close()
+
+    if !VerifySignature(h.Secret, body, req.Header.Get(SignatureHeader)) {
+        http.Error(w, "optidash: invalid webhook signature", http.StatusUnauthorized)
+        return
+    }
+
+    payload, binary, err := splitPayload(req.Header.Get("Content-Type"), body)
+    if err != nil {
+        http.Error(w, "optidash: malformed webhook payload", http.StatusBadRequest)
+        return
+    }
+
+    var result optidash.Result
+    if err := json.Unmarshal(payload, &result); err != nil {
+        http.Error(w, "optidash: malformed webhook payload", http.StatusBadRequest)
+        return
+    }
+
+    if h.Callback != nil {
+        h.Callback(req.Context(), &result, binary)
+    }
+
+    w.WriteHeader(http.StatusOK)
+}
+
+// splitPayload separates the JSON metadata from an optional attached binary
+// part. Plain JSON deliveries are returned as-is; multipart deliveries are
+// expected to carry the metadata in a "meta" field and the binary output,
+// if any, in a "file" part.
+func splitPayload(contentType string, body []byte) ([]byte, io.Reader, error) {
+    mediaType, params, err := mime.ParseMediaType(contentType)
+    if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+        return body, nil, nil
+    }
+
+    var (
+        payload []byte
+        binary  io.Reader
+    )
+
+    reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+    for {
+        part, err := reader.NextPart()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, nil, err
+        }
+
+        switch part.FormName() {
+        case "meta":
+            payload, err = ioutil.ReadAll(part)
+            if err != nil {
+                return nil, nil, err
+            }
+        case "file":
+            buf := &bytes.Buffer{}
+            if _, err := io.Copy(buf, part); err != nil {
+                return nil, nil, err
+            }
+            binary = buf
+        }
+    }
+
+    return payload, binary, nil
+}
+
+// VerifySignature reports whether signature is the hex-encoded HMAC-SHA256
+// of body keyed with secret, using a constant-time comparison.
+func VerifySignature(secret string, body []byte, signature string) bool {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write(body)
+    expected := hex.EncodeToString(mac.Sum(nil))
+    return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,50 @@
+package webhook
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "testing"
+)
+
+func sign(secret string, body []byte) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write(body)
+    return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignatureAcceptsValidSignature(t *testing.T) {
+    body := []byte(`{"success":true}`)
+    signature := sign("top-secret", body)
+
+    if !VerifySignature("top-secret", body, signature) {
+        t.Fatal("expected a valid signature to verify")
+    }
+}
+
+func TestVerifySignatureRejectsTamperedBody(t *testing.T) {
+    body := []byte(`{"success":true}`)
+    signature := sign("top-secret", body)
+
+    tampered := []byte(`{"success":false}`)
+    if VerifySignature("top-secret", tampered, signature) {
+        t.Fatal("expected a tampered body to fail verification")
+    }
+}
+
+func TestVerifySignatureRejectsWrongSecret(t *testing.T) {
+    body := []byte(`{"success":true}`)
+    signature := sign("top-secret", body)
+
+    if VerifySignature("wrong-secret", body, signature) {
+        t.Fatal("expected a signature keyed with the wrong secret to fail verification")
+    }
+}
+
+func TestVerifySignatureRejectsEmptySignature(t *testing.T) {
+    body := []byte(`{"success":true}`)
+
+    if VerifySignature("top-secret", body, "") {
+        t.Fatal("expected an empty signature to fail verification")
+    }
+}